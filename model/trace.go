@@ -0,0 +1,15 @@
+package model
+
+// Trace is a collection of spans that share the same TraceID.
+type Trace []*Span
+
+// GetRoot returns the root span of the trace, i.e. the span with no parent
+// within the trace. Falls back to the first span if none is found.
+func (t Trace) GetRoot() *Span {
+	for _, s := range t {
+		if s.ParentID == 0 {
+			return s
+		}
+	}
+	return t[0]
+}
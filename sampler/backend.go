@@ -0,0 +1,22 @@
+package sampler
+
+// Backend keeps track, per signature, of how many traces are seen and how
+// many are sampled, decayed over time, so that Sampler can derive a score
+// and an overall sampled throughput from it.
+type Backend interface {
+	// CountSignature counts an incoming trace for the given signature.
+	CountSignature(signature Signature)
+	// CountSample counts a trace that was kept for the given signature.
+	CountSample(signature Signature)
+	// GetSignatureScore returns the current score (roughly, traces per
+	// second seen) for the given signature.
+	GetSignatureScore(signature Signature) float64
+	// GetSampledScore returns the current overall sampled throughput,
+	// across all signatures.
+	GetSampledScore() float64
+	// GetMaxSampledScore returns a conservative (never-too-low) estimate
+	// of the current overall sampled throughput, suitable for admission
+	// control: unlike GetSampledScore, it must not underestimate the true
+	// rate at any point, even between two decay ticks.
+	GetMaxSampledScore() float64
+}
@@ -0,0 +1,33 @@
+package sampler
+
+import "github.com/DataDog/datadog-trace-agent/model"
+
+// DurationEngine unconditionally keeps any trace whose root span duration
+// falls within [MinDuration, MaxDuration] (either bound left at 0 disables
+// it), regardless of any score-based decision. Unlike a pure latency
+// filter, both bounds are configurable so a "slow but not pathological"
+// window can be targeted for keep-all behavior while the tail is still
+// left to the score engine.
+type DurationEngine struct {
+	MinDuration int64
+	MaxDuration int64
+}
+
+// NewDurationEngine returns a DurationEngine keeping traces whose root span
+// duration is within [minDuration, maxDuration].
+func NewDurationEngine(minDuration, maxDuration int64) *DurationEngine {
+	return &DurationEngine{MinDuration: minDuration, MaxDuration: maxDuration}
+}
+
+// Sample implements Engine.
+func (e *DurationEngine) Sample(trace model.Trace, root *model.Span, env string) bool {
+	if e.MinDuration > 0 && root.Duration < e.MinDuration {
+		return false
+	}
+	if e.MaxDuration > 0 && root.Duration > e.MaxDuration {
+		return false
+	}
+
+	applySampleRate(root, 1)
+	return true
+}
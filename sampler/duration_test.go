@@ -0,0 +1,68 @@
+package sampler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurationEngineLowerBoundOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	e := NewDurationEngine(500000, 0)
+
+	trace, root := getTestTrace()
+	root.Duration = 1000000
+	assert.True(e.Sample(trace, root, defaultEnv))
+
+	root.Duration = 499999
+	assert.False(e.Sample(trace, root, defaultEnv))
+}
+
+func TestDurationEngineUpperBoundOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	e := NewDurationEngine(0, 2000000)
+
+	trace, root := getTestTrace()
+	root.Duration = 1000000
+	assert.True(e.Sample(trace, root, defaultEnv))
+
+	root.Duration = 2000001
+	assert.False(e.Sample(trace, root, defaultEnv))
+}
+
+func TestDurationEngineBothBounds(t *testing.T) {
+	assert := assert.New(t)
+
+	e := NewDurationEngine(500000, 2000000)
+
+	trace, root := getTestTrace()
+
+	root.Duration = 499999
+	assert.False(e.Sample(trace, root, defaultEnv))
+
+	root.Duration = 1000000
+	assert.True(e.Sample(trace, root, defaultEnv))
+
+	root.Duration = 2000001
+	assert.False(e.Sample(trace, root, defaultEnv))
+}
+
+func TestMultiEngineOrsDecisionsAndTakesMaxRate(t *testing.T) {
+	assert := assert.New(t)
+
+	duration := NewDurationEngine(500000, 0)
+	score := getTestScoreEngine()
+	// Force the score engine to drop with a tiny rate so its contribution
+	// is clearly lower than the duration engine's full keep (rate 1).
+	score.Sampler.extraRate = 0.01
+
+	m := NewMultiEngine(duration, score)
+
+	trace, root := getTestTrace()
+	root.Duration = 1000000 // within the duration window
+
+	assert.True(m.Sample(trace, root, defaultEnv), "duration engine alone should keep this trace")
+	assert.Equal(1.0, GetTraceAppliedSampleRate(root), "the higher of the two engines' rates must win")
+}
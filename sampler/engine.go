@@ -0,0 +1,11 @@
+package sampler
+
+import "github.com/DataDog/datadog-trace-agent/model"
+
+// Engine is the interface implemented by all trace sampling algorithms used
+// by the agent. A sampling decision is taken per-trace, using the trace's
+// root span to carry any metadata the decision depends on.
+type Engine interface {
+	// Sample reports whether the trace should be kept.
+	Sample(trace model.Trace, root *model.Span, env string) bool
+}
@@ -0,0 +1,123 @@
+package sampler
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDecayPeriod is how often the in-memory counters are decayed.
+	defaultDecayPeriod = 5 * time.Second
+	// defaultDecayFactor is the factor counters are divided by on every
+	// decay period, giving recent traces more weight than older ones.
+	defaultDecayFactor = 1.125
+)
+
+// MemoryBackend is the in-process implementation of Backend. Counters are
+// decayed periodically (see decayScore) instead of using a true sliding
+// window, which keeps memory and CPU usage constant regardless of traffic.
+type MemoryBackend struct {
+	mu sync.RWMutex
+
+	countsSeen map[Signature]float64
+	countsKept map[Signature]float64
+
+	totalSeen float64
+	totalKept float64
+
+	decayPeriod time.Duration
+	decayFactor float64
+}
+
+// NewMemoryBackend returns a MemoryBackend decaying its counters every
+// decayPeriod by decayFactor.
+func NewMemoryBackend(decayPeriod time.Duration, decayFactor float64) *MemoryBackend {
+	b := &MemoryBackend{
+		countsSeen:  make(map[Signature]float64),
+		countsKept:  make(map[Signature]float64),
+		decayPeriod: decayPeriod,
+		decayFactor: decayFactor,
+	}
+
+	go b.run(decayPeriod)
+
+	return b
+}
+
+func (b *MemoryBackend) run(decayPeriod time.Duration) {
+	for range time.Tick(decayPeriod) {
+		b.decayScore()
+	}
+}
+
+// CountSignature implements Backend.
+func (b *MemoryBackend) CountSignature(signature Signature) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.countsSeen[signature]++
+	b.totalSeen++
+}
+
+// CountSample implements Backend.
+func (b *MemoryBackend) CountSample(signature Signature) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.countsKept[signature]++
+	b.totalKept++
+}
+
+// GetSignatureScore implements Backend.
+func (b *MemoryBackend) GetSignatureScore(signature Signature) float64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.countsSeen[signature] / b.decayPeriod.Seconds() * b.decayNormalizationFactor()
+}
+
+// GetSampledScore implements Backend.
+func (b *MemoryBackend) GetSampledScore() float64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.totalKept / b.decayPeriod.Seconds() * b.decayNormalizationFactor()
+}
+
+// GetMaxSampledScore implements Backend. GetSampledScore's correction is
+// exact only at the instant a decay tick fires; right after a tick it
+// trails the true rate by up to a factor of decayFactor, recovering as the
+// period wears on. Scaling it up by that same factor turns the lag into a
+// conservative, never-too-low estimate instead, at the cost of
+// over-estimating as a tick approaches.
+func (b *MemoryBackend) GetMaxSampledScore() float64 {
+	return b.GetSampledScore() * b.decayFactor
+}
+
+// decayNormalizationFactor corrects for decayScore dividing the counters by
+// decayFactor every period instead of resetting them: for a steady input of
+// tps events/s, the counters converge to tps*periodSeconds*decayFactor /
+// (decayFactor-1) instead of tps*periodSeconds, since every period's
+// contribution keeps getting re-added to what's left of every earlier
+// period's. Multiplying by its inverse, (decayFactor-1)/decayFactor, turns
+// that converged geometric sum back into a rate.
+func (b *MemoryBackend) decayNormalizationFactor() float64 {
+	return (b.decayFactor - 1) / b.decayFactor
+}
+
+// decayScore divides every counter by decayFactor, simulating the passing
+// of one decay period. It runs on a timer but is also called directly by
+// tests that need deterministic control over time.
+func (b *MemoryBackend) decayScore() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sig, v := range b.countsSeen {
+		b.countsSeen[sig] = v / b.decayFactor
+	}
+	for sig, v := range b.countsKept {
+		b.countsKept[sig] = v / b.decayFactor
+	}
+	b.totalSeen /= b.decayFactor
+	b.totalKept /= b.decayFactor
+}
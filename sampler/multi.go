@@ -0,0 +1,52 @@
+package sampler
+
+import "github.com/DataDog/datadog-trace-agent/model"
+
+// MultiEngine composes several engines, keeping a trace if any of them
+// would keep it. Unlike chaining engines one after another (which
+// multiplies their sample rates together), it applies the highest of their
+// individual sample rates, since a trace kept by one engine shouldn't be
+// penalized by how aggressively the others would have dropped it.
+type MultiEngine struct {
+	Engines []Engine
+}
+
+// NewMultiEngine returns a MultiEngine ORing the keep decisions of engines.
+func NewMultiEngine(engines ...Engine) *MultiEngine {
+	return &MultiEngine{Engines: engines}
+}
+
+// Sample implements Engine.
+func (m *MultiEngine) Sample(trace model.Trace, root *model.Span, env string) bool {
+	priorRate := GetTraceAppliedSampleRate(root)
+
+	var kept bool
+	var maxRate float64
+
+	for _, e := range m.Engines {
+		// Each engine is run against its own copy of the root's metrics,
+		// so the rate it contributes can be read back in isolation
+		// instead of being multiplied in with the others.
+		scratch := *root
+		scratch.Metrics = copyMetrics(root.Metrics)
+		SetTraceAppliedSampleRate(&scratch, priorRate)
+
+		if e.Sample(trace, &scratch, env) {
+			kept = true
+		}
+		if rate := GetTraceAppliedSampleRate(&scratch); rate > maxRate {
+			maxRate = rate
+		}
+	}
+
+	SetTraceAppliedSampleRate(root, maxRate)
+	return kept
+}
+
+func copyMetrics(m map[string]float64) map[string]float64 {
+	c := make(map[string]float64, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,29 @@
+package sampler
+
+import "github.com/DataDog/datadog-trace-agent/model"
+
+// Pipeline is the full sampling decision chain run by the agent for every
+// trace: Trace decides whether to keep the trace as a whole, then
+// SingleSpan (if configured) gets a chance to retain individual spans from
+// whatever the trace-level decision dropped.
+type Pipeline struct {
+	Trace      Engine
+	SingleSpan *SingleSpanSampler
+}
+
+// Sample runs trace through the pipeline. traceSampled is the trace-level
+// decision; kept is the set of spans the writer should actually emit (the
+// whole trace when traceSampled is true, otherwise whatever SingleSpan
+// salvaged, which may be empty).
+func (p *Pipeline) Sample(trace model.Trace, root *model.Span, env string) (traceSampled bool, kept model.Trace) {
+	traceSampled = p.Trace.Sample(trace, root, env)
+
+	if p.SingleSpan == nil {
+		if traceSampled {
+			return true, trace
+		}
+		return false, nil
+	}
+
+	return traceSampled, p.SingleSpan.Sample(trace, traceSampled)
+}
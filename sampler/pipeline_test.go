@@ -0,0 +1,48 @@
+package sampler
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-trace-agent/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineSampleKeepsWholeTraceWhenTraceLevelKeeps(t *testing.T) {
+	assert := assert.New(t)
+
+	trace, root := getTestTrace()
+	p := &Pipeline{Trace: &stubEngine{ret: true}}
+
+	traceSampled, kept := p.Sample(trace, root, defaultEnv)
+	assert.True(traceSampled)
+	assert.Equal(trace, kept)
+}
+
+func TestPipelineSampleDropsEverythingWithoutSingleSpan(t *testing.T) {
+	assert := assert.New(t)
+
+	trace, root := getTestTrace()
+	p := &Pipeline{Trace: &stubEngine{ret: false}}
+
+	traceSampled, kept := p.Sample(trace, root, defaultEnv)
+	assert.False(traceSampled)
+	assert.Equal(model.Trace(nil), kept)
+}
+
+func TestPipelineSampleSalvagesSingleSpansWhenTraceLevelDrops(t *testing.T) {
+	assert := assert.New(t)
+
+	tID := randomTraceID()
+	webSpan := &model.Span{TraceID: tID, SpanID: 1, ParentID: 0, Start: 42, Duration: 1000000, Service: "mcnulty", Name: "web.request"}
+	sqlSpan := &model.Span{TraceID: tID, SpanID: 2, ParentID: 1, Start: 100, Duration: 200000, Service: "mcnulty", Name: "sql.query"}
+	trace := model.Trace{webSpan, sqlSpan}
+
+	singleSpan, err := NewSingleSpanSampler([]SingleSpanRule{{Service: "mcnulty", Name: "sql.query", Rate: 1}})
+	assert.NoError(err)
+
+	p := &Pipeline{Trace: &stubEngine{ret: false}, SingleSpan: singleSpan}
+
+	traceSampled, kept := p.Sample(trace, webSpan, defaultEnv)
+	assert.False(traceSampled, "the trace-level decision is still a drop")
+	assert.Equal(model.Trace{sqlSpan}, kept, "only the span matching the single-span rule is salvaged")
+}
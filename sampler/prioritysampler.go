@@ -0,0 +1,67 @@
+package sampler
+
+import "github.com/DataDog/datadog-trace-agent/model"
+
+// SamplingPriorityKey is the metric tracers use to convey a client-assigned
+// sampling priority on the trace root.
+const SamplingPriorityKey = "_sampling_priority_v1"
+
+// Sampling priority values, as assigned by client tracers on the trace root.
+const (
+	// PriorityUserReject means the user asked to drop the trace.
+	PriorityUserReject = -1
+	// PriorityAutoReject means the tracer's own sampler decided to drop
+	// the trace; the agent is still free to keep it.
+	PriorityAutoReject = 0
+	// PriorityAutoKeep means the tracer's own sampler decided to keep the
+	// trace; the agent is still free to drop it.
+	PriorityAutoKeep = 1
+	// PriorityUserKeep means the user asked to keep the trace no matter
+	// what.
+	PriorityUserKeep = 2
+)
+
+// PrioritySamplingEngine is an Engine that honors the priority assigned by
+// the client tracer on the trace root. User priorities (UserReject,
+// UserKeep) are deterministic and bypass scoring entirely; automatic
+// priorities (AutoReject, AutoKeep) and traces with no priority at all fall
+// through to the wrapped score Engine, so older tracers that never set a
+// priority keep working exactly as before.
+type PrioritySamplingEngine struct {
+	Score Engine
+}
+
+// NewPrioritySamplingEngine returns a PrioritySamplingEngine delegating
+// non-user-assigned decisions to score.
+func NewPrioritySamplingEngine(score Engine) *PrioritySamplingEngine {
+	return &PrioritySamplingEngine{Score: score}
+}
+
+// Sample implements Engine.
+func (e *PrioritySamplingEngine) Sample(trace model.Trace, root *model.Span, env string) bool {
+	priority, ok := GetSamplingPriority(root)
+	if !ok {
+		return e.Score.Sample(trace, root, env)
+	}
+
+	switch {
+	case priority <= PriorityUserReject:
+		applySampleRate(root, 0)
+		return false
+	case priority >= PriorityUserKeep:
+		applySampleRate(root, 1)
+		return true
+	default:
+		return e.Score.Sample(trace, root, env)
+	}
+}
+
+// GetSamplingPriority returns the sampling priority assigned by the client
+// tracer on the trace root, and whether one was set at all.
+func GetSamplingPriority(root *model.Span) (int, bool) {
+	p, ok := root.Metrics[SamplingPriorityKey]
+	if !ok {
+		return 0, false
+	}
+	return int(p), true
+}
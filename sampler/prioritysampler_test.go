@@ -0,0 +1,108 @@
+package sampler
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-trace-agent/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestPriorityEngine() *PrioritySamplingEngine {
+	return NewPrioritySamplingEngine(getTestScoreEngine())
+}
+
+func setPriority(root *model.Span, priority int) {
+	if root.Metrics == nil {
+		root.Metrics = make(map[string]float64, 1)
+	}
+	root.Metrics[SamplingPriorityKey] = float64(priority)
+}
+
+func TestPrioritySamplerUserKeepOverridesMaxTPS(t *testing.T) {
+	assert := assert.New(t)
+
+	e := getTestPriorityEngine()
+	e.Score.(*ScoreEngine).Sampler.maxTPS = 0
+	e.Score.(*ScoreEngine).Sampler.extraRate = 0
+
+	trace, root := getTestTrace()
+	setPriority(root, PriorityUserKeep)
+
+	assert.True(e.Sample(trace, root, defaultEnv), "UserKeep must be kept even with maxTPS/extraRate at 0")
+	assert.Equal(1.0, GetTraceAppliedSampleRate(root))
+}
+
+func TestPrioritySamplerUserKeepOverridesActiveMaxTPS(t *testing.T) {
+	assert := assert.New(t)
+
+	e := getTestPriorityEngine()
+	backend := e.Score.(*ScoreEngine).Sampler.Backend.(*MemoryBackend)
+	e.Score.(*ScoreEngine).Sampler.maxTPS = 1
+
+	// Drive the backend's sampled score far above maxTPS, so applyMaxTPS
+	// would thin a trace going through the wrapped score engine directly.
+	priorTrace, _ := getTestTrace()
+	signature := testComputeSignature(priorTrace)
+	for i := 0; i < 1000; i++ {
+		backend.CountSample(signature)
+	}
+	assert.False(e.Score.(*ScoreEngine).Sampler.applyMaxTPS(1), "sanity check: applyMaxTPS must actually thin at this backend load")
+
+	trace, root := getTestTrace()
+	setPriority(root, PriorityUserKeep)
+
+	assert.True(e.Sample(trace, root, defaultEnv), "UserKeep must bypass maxTPS thinning even when the cap is active and would otherwise thin")
+	assert.Equal(1.0, GetTraceAppliedSampleRate(root))
+}
+
+func TestPrioritySamplerUserRejectIsDropped(t *testing.T) {
+	assert := assert.New(t)
+
+	e := getTestPriorityEngine()
+
+	trace, root := getTestTrace()
+	setPriority(root, PriorityUserReject)
+
+	assert.False(e.Sample(trace, root, defaultEnv))
+	assert.Equal(0.0, GetTraceAppliedSampleRate(root))
+}
+
+func TestPrioritySamplerAutoRejectComposesWithApplySampleRate(t *testing.T) {
+	assert := assert.New(t)
+
+	e := getTestPriorityEngine()
+	e.Score.(*ScoreEngine).Sampler.extraRate = 0.5
+
+	// A trace ID of 0 always falls on the "kept" side of sampledByRate for
+	// any positive rate, so the score engine's decision stays deterministic
+	// even though extraRate makes it a coin flip in general.
+	trace, root := getTestTrace()
+	root.TraceID = 0
+	setPriority(root, PriorityAutoReject)
+	SetTraceAppliedSampleRate(root, 0.8)
+
+	sampled := e.Sample(trace, root, defaultEnv)
+
+	// AutoReject still goes through the score engine, which here applies
+	// extraRate=0.5 on top of a score rate of 1, so applySampleRate must
+	// have combined that 0.5 with the pre-existing 0.8 rather than
+	// overriding it.
+	assert.True(sampled)
+	assert.Equal(0.4, GetTraceAppliedSampleRate(root))
+}
+
+func TestPrioritySamplerNoPriorityFallsBackToScore(t *testing.T) {
+	assert := assert.New(t)
+
+	e := getTestPriorityEngine()
+	trace, root := getTestTrace()
+
+	_, ok := GetSamplingPriority(root)
+	assert.False(ok, "test trace shouldn't carry a priority")
+
+	// With no priority set, the engine must behave exactly like its
+	// wrapped score engine: keep everything (default test engine has no
+	// maxTPS and a neutral extraRate) and record a sample rate of 1.
+	assert.True(e.Sample(trace, root, defaultEnv))
+	assert.Equal(1.0, GetTraceAppliedSampleRate(root))
+}
@@ -0,0 +1,142 @@
+package sampler
+
+import (
+	"math"
+	"time"
+)
+
+// RemoteSamplerConfig is the wire format of a sampler configuration update
+// delivered through the Datadog agent's remote-config endpoint. Rules is
+// optional: a nil slice leaves whatever rules are currently live untouched,
+// while an empty (non-nil) slice clears them. Rules deliberately has no
+// `omitempty`: that tag drops any zero-length slice, nil or not, which
+// would erase the nil-vs-empty distinction on the wire.
+type RemoteSamplerConfig struct {
+	ExtraRate            float64        `json:"extra_rate"`
+	MaxTPS               float64        `json:"max_tps"`
+	SignatureScoreOffset float64        `json:"signature_score_offset"`
+	Rules                []SamplingRule `json:"rules"`
+}
+
+// valid reports whether cfg is sane enough to apply: rates and offsets
+// can't be negative, extraRate is a multiplier on top of an
+// already-computed sample rate so it can't exceed 1, and every rule (if
+// any) has a rate in the same [0, 1] range.
+func (cfg *RemoteSamplerConfig) valid() bool {
+	if cfg == nil ||
+		cfg.ExtraRate < 0 || cfg.ExtraRate > 1 ||
+		cfg.MaxTPS < 0 ||
+		cfg.SignatureScoreOffset <= 0 {
+		return false
+	}
+	for _, r := range cfg.Rules {
+		if r.Rate < 0 || r.Rate > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// remoteConfig is the derived, ready-to-use form of RemoteSamplerConfig
+// stored in Sampler.remoteConfig. signatureScoreFactor is precomputed once
+// per update instead of on every sampling decision.
+type remoteConfig struct {
+	extraRate            float64
+	maxTPS               float64
+	signatureScoreOffset float64
+	signatureScoreFactor float64
+}
+
+func newRemoteConfig(cfg *RemoteSamplerConfig, signatureScoreSlope float64) *remoteConfig {
+	return &remoteConfig{
+		extraRate:            cfg.ExtraRate,
+		maxTPS:               cfg.MaxTPS,
+		signatureScoreOffset: cfg.SignatureScoreOffset,
+		signatureScoreFactor: math.Pow(signatureScoreSlope, math.Log10(cfg.SignatureScoreOffset)),
+	}
+}
+
+// RemoteConfigFetcher retrieves the latest sampler configuration from the
+// Datadog agent's remote-config endpoint. It should return a nil config
+// (not an error) when nothing changed.
+type RemoteConfigFetcher func() (*RemoteSamplerConfig, error)
+
+// RemoteConfigWatcher periodically polls a RemoteConfigFetcher and swaps
+// its result atomically into the Sampler (and, if watching one, the
+// RulesEngine) it watches, so updates take effect on the hot sampling path
+// without restarting the agent or taking a lock.
+type RemoteConfigWatcher struct {
+	sampler *Sampler
+	rules   *RulesEngine
+	fetch   RemoteConfigFetcher
+
+	stop chan struct{}
+}
+
+// NewRemoteConfigWatcher returns a watcher applying configuration fetched
+// by fetch to sampler. Call WatchRules to also have it swap in updated
+// rules on a RulesEngine sitting in front of sampler.
+func NewRemoteConfigWatcher(sampler *Sampler, fetch RemoteConfigFetcher) *RemoteConfigWatcher {
+	return &RemoteConfigWatcher{
+		sampler: sampler,
+		fetch:   fetch,
+		stop:    make(chan struct{}),
+	}
+}
+
+// WatchRules makes Apply also swap rules's compiled rule list with
+// cfg.Rules, whenever a delivered config carries one. Returns w for
+// chaining off NewRemoteConfigWatcher.
+func (w *RemoteConfigWatcher) WatchRules(rules *RulesEngine) *RemoteConfigWatcher {
+	w.rules = rules
+	return w
+}
+
+// Start polls fetch every interval until Stop is called, applying every
+// valid config it receives.
+func (w *RemoteConfigWatcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.poll()
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop terminates the polling loop started by Start.
+func (w *RemoteConfigWatcher) Stop() {
+	close(w.stop)
+}
+
+func (w *RemoteConfigWatcher) poll() {
+	cfg, err := w.fetch()
+	if err != nil {
+		return
+	}
+	w.Apply(cfg)
+}
+
+// Apply validates cfg and, if sane, atomically swaps it into the watched
+// sampler (and, if WatchRules was called, cfg.Rules into the watched
+// RulesEngine), reporting whether it was applied. This is also the hook
+// tests use to deliver synthetic config payloads without going through
+// Start's polling loop.
+func (w *RemoteConfigWatcher) Apply(cfg *RemoteSamplerConfig) bool {
+	if !cfg.valid() {
+		return false
+	}
+	if w.rules != nil && cfg.Rules != nil {
+		if err := w.rules.SetRules(cfg.Rules); err != nil {
+			return false
+		}
+	}
+	w.sampler.setRemoteConfig(newRemoteConfig(cfg, w.sampler.signatureScoreSlope))
+	return true
+}
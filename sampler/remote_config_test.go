@@ -0,0 +1,132 @@
+package sampler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteConfigWatcherAppliesMidStreamChange(t *testing.T) {
+	assert := assert.New(t)
+
+	s := getTestScoreEngine().Sampler
+	s.extraRate = 1.0
+	s.maxTPS = 0
+
+	w := NewRemoteConfigWatcher(s, func() (*RemoteSamplerConfig, error) { return nil, nil })
+
+	trace, root := getTestTrace()
+	signature := testComputeSignature(trace)
+
+	before := s.GetSampleRate(trace, root, signature)
+
+	ok := w.Apply(&RemoteSamplerConfig{ExtraRate: 0.1, MaxTPS: 0, SignatureScoreOffset: defaultSignatureScoreOffset})
+	assert.True(ok)
+
+	after := s.GetSampleRate(trace, root, signature)
+	assert.InDelta(before*0.1, after, 1e-9, "a new extraRate must take effect immediately, without restarting the sampler")
+}
+
+func TestRemoteConfigWatcherAppliesRulesWhenWatched(t *testing.T) {
+	assert := assert.New(t)
+
+	s := getTestScoreEngine().Sampler
+	rules, err := NewRulesEngine([]SamplingRule{{Service: "*", Name: "*", Rate: 1}}, &ScoreEngine{Sampler: s})
+	assert.NoError(err)
+
+	w := NewRemoteConfigWatcher(s, func() (*RemoteSamplerConfig, error) { return nil, nil }).WatchRules(rules)
+
+	ok := w.Apply(&RemoteSamplerConfig{
+		ExtraRate:            1,
+		SignatureScoreOffset: defaultSignatureScoreOffset,
+		Rules:                []SamplingRule{{Service: "*", Name: "*", Rate: 0.3}},
+	})
+	assert.True(ok)
+
+	trace, root := getTestTrace()
+	rules.Sample(trace, root, defaultEnv)
+	assert.Equal(0.3, GetTraceAppliedSampleRate(root), "a mid-stream rules change must take effect immediately")
+}
+
+func TestRemoteConfigWatcherWithoutWatchRulesIgnoresRules(t *testing.T) {
+	assert := assert.New(t)
+
+	s := getTestScoreEngine().Sampler
+	rules, err := NewRulesEngine([]SamplingRule{{Service: "*", Name: "*", Rate: 1}}, &ScoreEngine{Sampler: s})
+	assert.NoError(err)
+
+	w := NewRemoteConfigWatcher(s, func() (*RemoteSamplerConfig, error) { return nil, nil })
+
+	ok := w.Apply(&RemoteSamplerConfig{
+		ExtraRate:            1,
+		SignatureScoreOffset: defaultSignatureScoreOffset,
+		Rules:                []SamplingRule{{Service: "*", Name: "*", Rate: 0.3}},
+	})
+	assert.True(ok, "rules in the payload are simply ignored when WatchRules was never called")
+
+	trace, root := getTestTrace()
+	rules.Sample(trace, root, defaultEnv)
+	assert.Equal(1.0, GetTraceAppliedSampleRate(root), "rules must stay untouched without a WatchRules hookup")
+}
+
+func TestRemoteConfigWatcherRejectsMalformedPayload(t *testing.T) {
+	assert := assert.New(t)
+
+	s := getTestScoreEngine().Sampler
+	s.extraRate = 0.77
+
+	w := NewRemoteConfigWatcher(s, func() (*RemoteSamplerConfig, error) { return nil, nil })
+
+	trace, root := getTestTrace()
+	signature := testComputeSignature(trace)
+	before := s.GetSampleRate(trace, root, signature)
+
+	assert.False(w.Apply(&RemoteSamplerConfig{ExtraRate: -1, MaxTPS: 0, SignatureScoreOffset: 1}), "negative extraRate must be rejected")
+	assert.False(w.Apply(&RemoteSamplerConfig{ExtraRate: 0.5, MaxTPS: -5, SignatureScoreOffset: 1}), "negative maxTPS must be rejected")
+	assert.False(w.Apply(&RemoteSamplerConfig{ExtraRate: 0.5, MaxTPS: 0, SignatureScoreOffset: 0}), "non-positive signatureScoreOffset must be rejected")
+	assert.False(w.Apply(&RemoteSamplerConfig{ExtraRate: 0.5, MaxTPS: 0, SignatureScoreOffset: 1, Rules: []SamplingRule{{Rate: 1.5}}}), "an out-of-range rule rate must be rejected")
+	assert.False(w.Apply(nil), "a nil payload must be rejected")
+
+	after := s.GetSampleRate(trace, root, signature)
+	assert.Equal(before, after, "rejected payloads must leave the live config untouched")
+}
+
+func TestRemoteConfigWatcherPolling(t *testing.T) {
+	assert := assert.New(t)
+
+	s := getTestScoreEngine().Sampler
+
+	polled := make(chan struct{}, 1)
+	cfg := &RemoteSamplerConfig{ExtraRate: 0.2, MaxTPS: 0, SignatureScoreOffset: defaultSignatureScoreOffset}
+
+	w := NewRemoteConfigWatcher(s, func() (*RemoteSamplerConfig, error) {
+		select {
+		case polled <- struct{}{}:
+		default:
+		}
+		return cfg, nil
+	})
+
+	w.Start(10 * time.Millisecond)
+	defer w.Stop()
+
+	select {
+	case <-polled:
+	case <-time.After(time.Second):
+		t.Fatal("watcher never polled its fetcher")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	var rate float64
+	for time.Now().Before(deadline) {
+		trace, root := getTestTrace()
+		signature := testComputeSignature(trace)
+		rate = s.GetSampleRate(trace, root, signature)
+		if rate == 0.2 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(0.2, rate, "a mid-stream config change must take effect within one decay period")
+}
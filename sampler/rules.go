@@ -0,0 +1,121 @@
+package sampler
+
+import (
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-trace-agent/model"
+)
+
+// SamplingRule matches traces by service and operation name, glob-style
+// (`*` matches any run of characters, `?` matches exactly one), and assigns
+// them a fixed sample rate.
+type SamplingRule struct {
+	Service string  `json:"service"`
+	Name    string  `json:"name"`
+	Rate    float64 `json:"sample_rate"`
+}
+
+// compiledRule is a SamplingRule with its glob patterns pre-compiled to
+// anchored, case-insensitive regexes.
+type compiledRule struct {
+	rule    SamplingRule
+	service *regexp.Regexp
+	name    *regexp.Regexp
+}
+
+// globToRegexp compiles a glob pattern supporting `*` and `?` into an
+// anchored, case-insensitive regexp matching the whole string. An empty
+// pattern matches anything.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	escaped := regexp.QuoteMeta(pattern)
+	escaped = strings.Replace(escaped, `\*`, ".*", -1)
+	escaped = strings.Replace(escaped, `\?`, ".", -1)
+	return regexp.Compile("(?i)^" + escaped + "$")
+}
+
+func newCompiledRule(rule SamplingRule) (*compiledRule, error) {
+	service, err := globToRegexp(rule.Service)
+	if err != nil {
+		return nil, err
+	}
+	name, err := globToRegexp(rule.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &compiledRule{rule: rule, service: service, name: name}, nil
+}
+
+func (c *compiledRule) match(root *model.Span) bool {
+	return c.service.MatchString(root.Service) && c.name.MatchString(root.Name)
+}
+
+// compileRules compiles every rule in rules, in order, stopping at the
+// first one that fails to compile.
+func compileRules(rules []SamplingRule) ([]*compiledRule, error) {
+	compiled := make([]*compiledRule, 0, len(rules))
+	for _, r := range rules {
+		c, err := newCompiledRule(r)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// RulesEngine is consulted before the score engine in the sampler pipeline:
+// the first configured rule matching a trace's root span decides it
+// deterministically; if nothing matches, the decision is deferred to
+// Fallback.
+type RulesEngine struct {
+	Fallback Engine
+
+	// rules holds the current []*compiledRule. It's read on every sampling
+	// decision, so SetRules (and the RemoteConfigWatcher that calls it) can
+	// swap in a new rule list without ever taking a lock on the hot path.
+	rules atomic.Value
+}
+
+// NewRulesEngine compiles rules and returns a RulesEngine deferring to
+// fallback when none of them match a trace.
+func NewRulesEngine(rules []SamplingRule, fallback Engine) (*RulesEngine, error) {
+	compiled, err := compileRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &RulesEngine{Fallback: fallback}
+	e.rules.Store(compiled)
+
+	return e, nil
+}
+
+// SetRules compiles rules and, if they all compile, atomically swaps them
+// in as the rules in effect; the live rules are left untouched if any of
+// them fails to compile.
+func (e *RulesEngine) SetRules(rules []SamplingRule) error {
+	compiled, err := compileRules(rules)
+	if err != nil {
+		return err
+	}
+
+	e.rules.Store(compiled)
+	return nil
+}
+
+// Sample implements Engine.
+func (e *RulesEngine) Sample(trace model.Trace, root *model.Span, env string) bool {
+	rules, _ := e.rules.Load().([]*compiledRule)
+	for _, c := range rules {
+		if !c.match(root) {
+			continue
+		}
+		sampled := sampledByRate(root.TraceID, c.rule.Rate)
+		applySampleRate(root, c.rule.Rate)
+		return sampled
+	}
+
+	return e.Fallback.Sample(trace, root, env)
+}
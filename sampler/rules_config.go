@@ -0,0 +1,66 @@
+package sampler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	// samplingRulesEnvVar carries a JSON array of SamplingRule, as set by
+	// the Datadog Agent.
+	samplingRulesEnvVar = "DD_APM_SAMPLING_RULES"
+	// samplingRulesFileEnvVar points to a JSON file with the same format,
+	// used when the rules don't fit comfortably in an environment
+	// variable. Takes precedence over samplingRulesEnvVar.
+	samplingRulesFileEnvVar = "DD_APM_SAMPLING_RULES_FILE"
+	// globalSampleRateEnvVar, if set, is appended as a catch-all rule
+	// after every configured rule, so traces that match nothing still get
+	// a deterministic, configurable rate instead of falling through to
+	// the score engine.
+	globalSampleRateEnvVar = "DD_APM_SAMPLING_RATE"
+)
+
+// LoadSamplingRules loads the ordered list of sampling rules configured for
+// this agent, from DD_APM_SAMPLING_RULES_FILE if set, falling back to
+// DD_APM_SAMPLING_RULES, and appends a catch-all rule for
+// DD_APM_SAMPLING_RATE if set. It returns a nil slice, not an error, when
+// nothing is configured.
+func LoadSamplingRules() ([]SamplingRule, error) {
+	var rules []SamplingRule
+	var err error
+
+	switch {
+	case os.Getenv(samplingRulesFileEnvVar) != "":
+		var data []byte
+		if data, err = ioutil.ReadFile(os.Getenv(samplingRulesFileEnvVar)); err != nil {
+			return nil, err
+		}
+		if rules, err = parseSamplingRules(data); err != nil {
+			return nil, err
+		}
+	case os.Getenv(samplingRulesEnvVar) != "":
+		if rules, err = parseSamplingRules([]byte(os.Getenv(samplingRulesEnvVar))); err != nil {
+			return nil, err
+		}
+	}
+
+	if raw := os.Getenv(globalSampleRateEnvVar); raw != "" {
+		var rate float64
+		if _, err := fmt.Sscanf(raw, "%g", &rate); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", globalSampleRateEnvVar, err)
+		}
+		rules = append(rules, SamplingRule{Service: "*", Name: "*", Rate: rate})
+	}
+
+	return rules, nil
+}
+
+func parseSamplingRules(data []byte) ([]SamplingRule, error) {
+	var rules []SamplingRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
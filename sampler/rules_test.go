@@ -0,0 +1,96 @@
+package sampler
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-trace-agent/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubEngine is a bare-bones Engine used to check RulesEngine's fallback
+// wiring without depending on ScoreEngine's own sampling behavior.
+type stubEngine struct {
+	called bool
+	ret    bool
+}
+
+func (s *stubEngine) Sample(trace model.Trace, root *model.Span, env string) bool {
+	s.called = true
+	return s.ret
+}
+
+func TestGlobToRegexpWildcard(t *testing.T) {
+	assert := assert.New(t)
+
+	re, err := globToRegexp("web.*")
+	assert.NoError(err)
+	assert.True(re.MatchString("web.request"))
+	assert.True(re.MatchString("WEB.REQUEST"), "matching should be case-insensitive")
+	assert.False(re.MatchString("webrequest"), "the literal dot must still be required")
+}
+
+func TestGlobToRegexpSingleChar(t *testing.T) {
+	assert := assert.New(t)
+
+	re, err := globToRegexp("?art")
+	assert.NoError(err)
+	assert.True(re.MatchString("cart"))
+	assert.True(re.MatchString("dart"))
+	assert.False(re.MatchString("art"), "? must match exactly one character")
+	assert.False(re.MatchString("smart"))
+}
+
+func TestGlobToRegexpLiteralDot(t *testing.T) {
+	assert := assert.New(t)
+
+	re, err := globToRegexp("web.request")
+	assert.NoError(err)
+	assert.True(re.MatchString("web.request"))
+	assert.False(re.MatchString("webXrequest"), "a literal dot in the pattern must not behave as a wildcard")
+}
+
+func TestRulesEnginePrecedence(t *testing.T) {
+	assert := assert.New(t)
+
+	rules := []SamplingRule{
+		{Service: "web*", Name: "*", Rate: 0.5},
+		{Service: "*", Name: "*", Rate: 0.1},
+	}
+	e, err := NewRulesEngine(rules, &stubEngine{})
+	assert.NoError(err)
+
+	trace, root := getTestTrace()
+	root.Service = "web-server"
+	root.Name = "http.request"
+
+	e.Sample(trace, root, defaultEnv)
+	assert.Equal(0.5, GetTraceAppliedSampleRate(root), "the first matching rule must win")
+}
+
+func TestRulesEngineSetRulesTakesEffectImmediately(t *testing.T) {
+	assert := assert.New(t)
+
+	e, err := NewRulesEngine([]SamplingRule{{Service: "*", Name: "*", Rate: 1}}, &stubEngine{})
+	assert.NoError(err)
+
+	assert.NoError(e.SetRules([]SamplingRule{{Service: "*", Name: "*", Rate: 0.3}}))
+
+	trace, root := getTestTrace()
+	e.Sample(trace, root, defaultEnv)
+	assert.Equal(0.3, GetTraceAppliedSampleRate(root), "SetRules must replace the live rules without rebuilding the engine")
+}
+
+func TestRulesEngineFallsBackWhenNoRuleMatches(t *testing.T) {
+	assert := assert.New(t)
+
+	fallback := &stubEngine{ret: true}
+	e, err := NewRulesEngine([]SamplingRule{{Service: "other-service", Name: "*", Rate: 1}}, fallback)
+	assert.NoError(err)
+
+	trace, root := getTestTrace()
+	root.Service = "mcnulty"
+
+	sampled := e.Sample(trace, root, defaultEnv)
+	assert.True(fallback.called, "no rule matches mcnulty, RulesEngine must defer to fallback")
+	assert.True(sampled)
+}
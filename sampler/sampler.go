@@ -0,0 +1,156 @@
+package sampler
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-trace-agent/model"
+)
+
+const (
+	// defaultSignatureScoreSlope controls how fast the sample rate decays
+	// as a signature's score grows past signatureScoreOffset.
+	defaultSignatureScoreSlope = 3.0
+	// defaultSignatureScoreOffset is the score (traces/s for a signature)
+	// below which all traces of that signature are kept.
+	defaultSignatureScoreOffset = 1.0
+
+	// knuthFactor is used to scramble the trace ID into a uniformly
+	// distributed value, so that sampling decisions on a given trace ID
+	// are deterministic and stable across the pipeline.
+	knuthFactor = 1111111111111111111
+)
+
+// Sampler implements the score-based sampling algorithm: traces belonging
+// to high-throughput signatures get sampled more aggressively than rare
+// ones, and an optional maxTPS budget caps the overall kept throughput.
+type Sampler struct {
+	Backend Backend
+
+	extraRate float64
+	maxTPS    float64
+
+	signatureScoreOffset float64
+	signatureScoreSlope  float64
+	signatureScoreFactor float64
+
+	// remoteConfig, when non-nil, holds a *remoteConfig overriding
+	// extraRate/maxTPS/signatureScoreOffset/signatureScoreFactor above.
+	// It's read on every sampling decision, so RemoteConfigWatcher can
+	// swap it in without ever taking a lock on the hot path.
+	remoteConfig atomic.Value
+}
+
+// config returns the tunables currently in effect: whatever was last
+// delivered through setRemoteConfig, falling back to the sampler's own
+// static configuration when no remote config has been applied yet.
+func (s *Sampler) config() (extraRate, maxTPS, signatureScoreOffset, signatureScoreFactor float64) {
+	if v := s.remoteConfig.Load(); v != nil {
+		cfg := v.(*remoteConfig)
+		return cfg.extraRate, cfg.maxTPS, cfg.signatureScoreOffset, cfg.signatureScoreFactor
+	}
+	return s.extraRate, s.maxTPS, s.signatureScoreOffset, s.signatureScoreFactor
+}
+
+// setRemoteConfig atomically swaps in a new remote configuration.
+func (s *Sampler) setRemoteConfig(cfg *remoteConfig) {
+	s.remoteConfig.Store(cfg)
+}
+
+// NewSampler returns a new Sampler applying extraRate on top of its
+// score-based decision and capping the overall kept throughput at maxTPS
+// (a maxTPS of 0 disables the cap).
+func NewSampler(extraRate float64, maxTPS float64) *Sampler {
+	s := &Sampler{
+		Backend: NewMemoryBackend(defaultDecayPeriod, defaultDecayFactor),
+
+		extraRate: extraRate,
+		maxTPS:    maxTPS,
+
+		signatureScoreOffset: defaultSignatureScoreOffset,
+		signatureScoreSlope:  defaultSignatureScoreSlope,
+	}
+	s.signatureScoreFactor = math.Pow(s.signatureScoreSlope, math.Log10(s.signatureScoreOffset))
+
+	return s
+}
+
+// signatureSampleRate returns the score-based sample rate for a signature,
+// ignoring extraRate and the maxTPS cap.
+func (s *Sampler) signatureSampleRate(signature Signature) float64 {
+	_, _, offset, factor := s.config()
+
+	score := s.Backend.GetSignatureScore(signature)
+	if score <= offset {
+		return 1
+	}
+	return factor * math.Pow(score, -1/s.signatureScoreSlope)
+}
+
+// GetSampleRate returns the sample rate that would currently be applied to
+// a trace with the given signature, including extraRate but not the maxTPS
+// cap (which depends on the global sampled throughput at decision time).
+func (s *Sampler) GetSampleRate(trace model.Trace, root *model.Span, signature Signature) float64 {
+	extraRate, _, _, _ := s.config()
+
+	rate := s.signatureSampleRate(signature) * extraRate
+	if rate > 1 {
+		rate = 1
+	}
+	return rate
+}
+
+// Sample runs the score-based sampling algorithm against trace, counting it
+// against its signature and returning whether it should be kept.
+func (s *Sampler) Sample(trace model.Trace, root *model.Span, env string) bool {
+	signature := computeSignature(trace, env)
+
+	s.Backend.CountSignature(signature)
+
+	sampleRate := s.GetSampleRate(trace, root, signature)
+	sampled := sampledByRate(root.TraceID, sampleRate)
+
+	// applySampleRate records the rate this decision was made at regardless
+	// of the coin flip's outcome, so a chain of samplers agrees on the
+	// trace's overall sampling odds even when this sampler drops it.
+	applySampleRate(root, sampleRate)
+
+	if sampled {
+		s.Backend.CountSample(signature)
+		sampled = s.applyMaxTPS(root.TraceID)
+	}
+
+	return sampled
+}
+
+// applyMaxTPS further thins out already-sampled traces so that the overall
+// kept throughput stays under maxTPS. It is a no-op when maxTPS is 0.
+func (s *Sampler) applyMaxTPS(traceID uint64) bool {
+	_, maxTPS, _, _ := s.config()
+
+	if maxTPS <= 0 {
+		return true
+	}
+
+	// GetMaxSampledScore (rather than GetSampledScore) is used here since
+	// admission control must never under-thin: GetSampledScore trails the
+	// true rate by up to a factor of decayFactor between two decay ticks,
+	// which would let maxTPS be overshot for most of every period.
+	currentTPS := s.Backend.GetMaxSampledScore()
+	if currentTPS <= maxTPS {
+		return true
+	}
+
+	return sampledByRate(traceID, maxTPS/currentTPS)
+}
+
+// sampledByRate deterministically decides, from the trace ID alone, whether
+// a trace should be kept at the given rate. Using the trace ID (rather than
+// a fresh random draw) ensures every sampler in the pipeline agrees on the
+// same traces for a given rate.
+func sampledByRate(traceID uint64, rate float64) bool {
+	if rate < 1 {
+		return traceID*knuthFactor < uint64(rate*math.MaxUint64)
+	}
+	return true
+}
@@ -0,0 +1,23 @@
+package sampler
+
+import "github.com/DataDog/datadog-trace-agent/model"
+
+// ScoreEngine is the default Engine: it keeps traces probabilistically based
+// on a score derived from how often their signature is seen, so that rare
+// signatures are kept preferentially over frequent ones.
+type ScoreEngine struct {
+	Sampler *Sampler
+}
+
+// NewScoreEngine returns a new ScoreEngine applying extraRate on top of its
+// score-based decision and capping the overall kept throughput at maxTPS.
+func NewScoreEngine(extraRate float64, maxTPS float64) *ScoreEngine {
+	return &ScoreEngine{
+		Sampler: NewSampler(extraRate, maxTPS),
+	}
+}
+
+// Sample implements Engine.
+func (s *ScoreEngine) Sample(trace model.Trace, root *model.Span, env string) bool {
+	return s.Sampler.Sample(trace, root, env)
+}
@@ -62,7 +62,7 @@ func TestMaxTPS(t *testing.T) {
 	tps := 100.0
 	// To avoid the edge effects from an non-initialized sampler, wait a bit before counting samples.
 	initPeriods := 20
-	periods := 50
+	periods := 200
 
 	s.Sampler.maxTPS = maxTPS
 	periodSeconds := defaultDecayPeriod.Seconds()
@@ -88,7 +88,10 @@ func TestMaxTPS(t *testing.T) {
 	// Check that the sampled score pre-maxTPS is equals to the incoming number of traces per second
 	assert.InEpsilon(tps, s.Sampler.Backend.GetSampledScore(), 0.01)
 
-	// We should have kept less traces per second than maxTPS
+	// We should have kept less than, or very close to, maxTPS traces per
+	// second: applyMaxTPS scales GetSampledScore up by decayFactor before
+	// comparing against maxTPS specifically so this holds at zero extra
+	// tolerance, instead of merely "on average".
 	assert.True(s.Sampler.maxTPS >= float64(sampledCount)/(float64(periods)*periodSeconds))
 
 	// We should have a throughput of sampled traces around maxTPS
@@ -133,6 +136,33 @@ func TestApplySampleRate(t *testing.T) {
 	assert.Equal(0.2, root.Metrics["_sample_rate"], "sample rate should be 20%% (50%% of 40%%)")
 }
 
+func TestApplySampleRateRoundingAndClamping(t *testing.T) {
+	assert := assert.New(t)
+	tID := randomTraceID()
+
+	root := model.Span{TraceID: tID, SpanID: 1, ParentID: 0, Start: 123, Duration: 100000, Service: "mcnulty", Type: "web"}
+	SetTraceAppliedSampleRate(&root, 0.00005)
+	assert.Equal(0.0001, root.Metrics["_sample_rate"], "0.00005 rounds up (half away from zero) to the smallest representable rate")
+
+	root = model.Span{TraceID: tID, SpanID: 1, ParentID: 0, Start: 123, Duration: 100000, Service: "mcnulty", Type: "web"}
+	SetTraceAppliedSampleRate(&root, 0.123456789)
+	assert.Equal(0.1235, root.Metrics["_sample_rate"], "extra precision beyond 4 decimal places is rounded off")
+
+	root = model.Span{TraceID: tID, SpanID: 1, ParentID: 0, Start: 123, Duration: 100000, Service: "mcnulty", Type: "web"}
+	for i := 0; i < 10; i++ {
+		applySampleRate(&root, 0.1)
+	}
+	assert.Equal(0.0001, root.Metrics["_sample_rate"], "a long multiplication chain must be clamped, never silently rounded to 0")
+
+	root = model.Span{TraceID: tID, SpanID: 1, ParentID: 0, Start: 123, Duration: 100000, Service: "mcnulty", Type: "web"}
+	SetTraceAppliedSampleRate(&root, 0)
+	assert.Equal(0.0, root.Metrics["_sample_rate"], "an exact 0 must be preserved, not clamped")
+
+	root = model.Span{TraceID: tID, SpanID: 1, ParentID: 0, Start: 123, Duration: 100000, Service: "mcnulty", Type: "web"}
+	SetTraceAppliedSampleRate(&root, 1)
+	assert.Equal(1.0, root.Metrics["_sample_rate"], "an exact 1 must be preserved")
+}
+
 func BenchmarkSampler(b *testing.B) {
 	// Benchmark the resource consumption of many traces sampling
 
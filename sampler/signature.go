@@ -0,0 +1,27 @@
+package sampler
+
+import (
+	"hash/fnv"
+
+	"github.com/DataDog/datadog-trace-agent/model"
+)
+
+// Signature identifies a group of traces that are alike (same service,
+// operation name and environment), used to score and sample them together.
+type Signature uint64
+
+// computeSignature returns the Signature of a trace, derived from its root
+// span's service and operation name and the environment it was reported
+// from.
+func computeSignature(trace model.Trace, env string) Signature {
+	root := trace.GetRoot()
+
+	h := fnv.New64a()
+	h.Write([]byte(env))
+	h.Write([]byte{0})
+	h.Write([]byte(root.Service))
+	h.Write([]byte{0})
+	h.Write([]byte(root.Name))
+
+	return Signature(h.Sum64())
+}
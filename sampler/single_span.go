@@ -0,0 +1,124 @@
+package sampler
+
+import (
+	"math"
+	"regexp"
+
+	"golang.org/x/time/rate"
+
+	"github.com/DataDog/datadog-trace-agent/model"
+)
+
+// Single-span sampling metrics, stamped on every span it decides to keep.
+const (
+	// SingleSpanSamplingMechanismKey flags a span as kept by single-span
+	// sampling rather than by the trace-level decision.
+	SingleSpanSamplingMechanismKey = "_dd.span_sampling.mechanism"
+	// SingleSpanSamplingMechanismRule is the only mechanism implemented so
+	// far: a user-configured rule.
+	SingleSpanSamplingMechanismRule = 8
+	// SingleSpanSamplingRuleRateKey carries the rate of the rule that kept
+	// the span.
+	SingleSpanSamplingRuleRateKey = "_dd.span_sampling.rule_rate"
+	// SingleSpanSamplingMaxPerSecondKey carries the rule's MaxPerSecond,
+	// when it has one.
+	SingleSpanSamplingMaxPerSecondKey = "_dd.span_sampling.max_per_second"
+)
+
+// SingleSpanRule configures single-span sampling: spans matching Service
+// and Name (glob-style, see SamplingRule) are kept individually, even when
+// the trace they belong to is dropped, at up to MaxPerSecond (0 disables
+// the limiter) and with probability Rate.
+type SingleSpanRule struct {
+	Service      string
+	Name         string
+	Rate         float64
+	MaxPerSecond float64
+}
+
+type compiledSingleSpanRule struct {
+	rule    SingleSpanRule
+	service *regexp.Regexp
+	name    *regexp.Regexp
+	limiter *rate.Limiter
+}
+
+// SingleSpanSampler runs after the trace-level sampling decision: for a
+// trace the trace-level samplers dropped, it still retains the individual
+// spans matching its rules, so the writer can emit a partial trace instead
+// of discarding it outright.
+type SingleSpanSampler struct {
+	rules []*compiledSingleSpanRule
+}
+
+// NewSingleSpanSampler compiles rules into a SingleSpanSampler.
+func NewSingleSpanSampler(rules []SingleSpanRule) (*SingleSpanSampler, error) {
+	s := &SingleSpanSampler{}
+
+	for _, r := range rules {
+		service, err := globToRegexp(r.Service)
+		if err != nil {
+			return nil, err
+		}
+		name, err := globToRegexp(r.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		var limiter *rate.Limiter
+		if r.MaxPerSecond > 0 {
+			limiter = rate.NewLimiter(rate.Limit(r.MaxPerSecond), int(math.Ceil(r.MaxPerSecond)))
+		}
+
+		s.rules = append(s.rules, &compiledSingleSpanRule{rule: r, service: service, name: name, limiter: limiter})
+	}
+
+	return s, nil
+}
+
+// Sample applies single-span sampling to trace. When traceSampled is true
+// the whole trace is already being kept and is returned unmodified;
+// otherwise only the spans matching a rule (and passing its rate and
+// MaxPerSecond limiter) are returned.
+func (s *SingleSpanSampler) Sample(trace model.Trace, traceSampled bool) model.Trace {
+	if traceSampled {
+		return trace
+	}
+
+	var kept model.Trace
+	for _, span := range trace {
+		if s.sampleSpan(span) {
+			kept = append(kept, span)
+		}
+	}
+	return kept
+}
+
+func (s *SingleSpanSampler) sampleSpan(span *model.Span) bool {
+	for _, c := range s.rules {
+		if !c.service.MatchString(span.Service) || !c.name.MatchString(span.Name) {
+			continue
+		}
+		if !sampledByRate(span.SpanID, c.rule.Rate) {
+			return false
+		}
+		if c.limiter != nil && !c.limiter.Allow() {
+			return false
+		}
+
+		stampSingleSpanSampling(span, c.rule)
+		return true
+	}
+	return false
+}
+
+func stampSingleSpanSampling(span *model.Span, rule SingleSpanRule) {
+	if span.Metrics == nil {
+		span.Metrics = make(map[string]float64, 3)
+	}
+	span.Metrics[SingleSpanSamplingMechanismKey] = SingleSpanSamplingMechanismRule
+	span.Metrics[SingleSpanSamplingRuleRateKey] = rule.Rate
+	if rule.MaxPerSecond > 0 {
+		span.Metrics[SingleSpanSamplingMaxPerSecondKey] = rule.MaxPerSecond
+	}
+}
@@ -0,0 +1,63 @@
+package sampler
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-trace-agent/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSingleSpanSamplerKeepsMatchingSpanMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewSingleSpanSampler([]SingleSpanRule{{Service: "mcnulty", Name: "sql.query", Rate: 1}})
+	assert.NoError(err)
+
+	tID := randomTraceID()
+	trace := model.Trace{
+		&model.Span{TraceID: tID, SpanID: 1, Service: "mcnulty", Name: "web.request"},
+		&model.Span{TraceID: tID, SpanID: 2, ParentID: 1, Service: "mcnulty", Name: "sql.query"},
+	}
+
+	kept := s.Sample(trace, false)
+
+	assert.Len(kept, 1)
+	assert.Equal(uint64(2), kept[0].SpanID)
+	assert.Equal(float64(SingleSpanSamplingMechanismRule), kept[0].Metrics[SingleSpanSamplingMechanismKey])
+	assert.Equal(1.0, kept[0].Metrics[SingleSpanSamplingRuleRateKey])
+	_, hasMaxPerSecond := kept[0].Metrics[SingleSpanSamplingMaxPerSecondKey]
+	assert.False(hasMaxPerSecond, "max_per_second metric must be absent when the rule doesn't set one")
+}
+
+func TestSingleSpanSamplerFullyKeptTraceUnaffected(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewSingleSpanSampler([]SingleSpanRule{{Service: "mcnulty", Name: "sql.query", Rate: 0}})
+	assert.NoError(err)
+
+	trace, _ := getTestTrace()
+	kept := s.Sample(trace, true)
+
+	assert.Equal(trace, kept, "single-span sampling must not touch traces already kept at the trace level")
+}
+
+func TestSingleSpanSamplerMaxPerSecond(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewSingleSpanSampler([]SingleSpanRule{{Service: "mcnulty", Name: "sql.query", Rate: 1, MaxPerSecond: 1}})
+	assert.NoError(err)
+
+	kept := 0
+	for i := 0; i < 100; i++ {
+		tID := randomTraceID()
+		trace := model.Trace{
+			&model.Span{TraceID: tID, SpanID: uint64(i + 1), Service: "mcnulty", Name: "sql.query"},
+		}
+		if len(s.Sample(trace, false)) == 1 {
+			kept++
+		}
+	}
+
+	assert.True(kept < 100, "the limiter must drop spans once MaxPerSecond is exceeded")
+	assert.True(kept >= 1, "the limiter must allow at least the initial burst through")
+}
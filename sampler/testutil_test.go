@@ -0,0 +1,19 @@
+package sampler
+
+import (
+	"math/rand"
+
+	"github.com/DataDog/datadog-trace-agent/model"
+)
+
+// randomTraceID returns a random trace ID, good enough to exercise the
+// sampling algorithms in tests without colliding.
+func randomTraceID() uint64 {
+	return uint64(rand.Int63())
+}
+
+// testComputeSignature is a test-only shortcut for computeSignature using
+// the default test environment.
+func testComputeSignature(trace model.Trace) Signature {
+	return computeSignature(trace, defaultEnv)
+}
@@ -0,0 +1,66 @@
+package sampler
+
+import (
+	"math"
+
+	"github.com/DataDog/datadog-trace-agent/model"
+)
+
+// SampleRateMetricKey is the metric carrying the cumulative sample rate
+// applied to a trace by every sampler it went through.
+const SampleRateMetricKey = "_sample_rate"
+
+// sampleRatePrecision is how many decimal places the applied sample rate is
+// rounded to before being stored, so chained multiplications don't grow an
+// ever-longer float tail.
+const sampleRatePrecision = 4
+
+// GetTraceAppliedSampleRate returns the cumulative sample rate already
+// applied to a trace, or 1 if none was applied yet.
+func GetTraceAppliedSampleRate(root *model.Span) float64 {
+	if rate, ok := root.Metrics[SampleRateMetricKey]; ok {
+		return rate
+	}
+	return 1
+}
+
+// SetTraceAppliedSampleRate sets the cumulative sample rate applied to a
+// trace, rounded to sampleRatePrecision decimal places (half away from
+// zero). Any positive rate that would round down to 0 is instead clamped to
+// the smallest representable rate at that precision, so a sampler that kept
+// a trace never reports it as having a 0% chance of being kept; 0 and 1
+// themselves are left untouched.
+func SetTraceAppliedSampleRate(root *model.Span, sampleRate float64) {
+	if root.Metrics == nil {
+		root.Metrics = make(map[string]float64, 1)
+	}
+	root.Metrics[SampleRateMetricKey] = roundSampleRate(sampleRate)
+}
+
+// applySampleRate combines sampleRate with whatever rate was already applied
+// to the trace (by an earlier sampler in the pipeline) and stores the result
+// on the trace root.
+func applySampleRate(root *model.Span, sampleRate float64) {
+	newRate := GetTraceAppliedSampleRate(root) * sampleRate
+	SetTraceAppliedSampleRate(root, newRate)
+}
+
+// roundSampleRate rounds rate to sampleRatePrecision decimal places, half
+// away from zero, clamping any positive result that would round to 0 up to
+// the smallest representable rate instead. 0 and 1 are always preserved
+// exactly.
+func roundSampleRate(rate float64) float64 {
+	if rate <= 0 {
+		return 0
+	}
+	if rate >= 1 {
+		return 1
+	}
+
+	scale := math.Pow10(sampleRatePrecision)
+	rounded := math.Floor(rate*scale+0.5) / scale
+	if rounded <= 0 {
+		return 1 / scale
+	}
+	return rounded
+}